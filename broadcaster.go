@@ -0,0 +1,289 @@
+package sse
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// DropPolicy selects what a Broadcaster does when a subscriber's queue is
+// full, i.e. the subscriber is not draining writes as fast as they arrive.
+type DropPolicy int
+
+const (
+	// DropOldest discards the oldest queued frame to make room.
+	DropOldest DropPolicy = iota
+
+	// DropNewest discards the frame that was about to be enqueued.
+	DropNewest
+
+	// DisconnectSlowConsumer removes the subscriber immediately.
+	DisconnectSlowConsumer
+
+	// BlockWithTimeout waits for room in the queue, up to BlockTimeout,
+	// then disconnects the subscriber.
+	BlockWithTimeout
+)
+
+const (
+	DefaultQueueSize    = 16
+	DefaultBlockTimeout = 1 * time.Second
+)
+
+// BroadcasterMetrics are optional hooks a Broadcaster calls out to so an
+// operator can observe subscriber health. Any hook may be left nil.
+type BroadcasterMetrics struct {
+	// QueueDepth is called before every enqueue attempt with the
+	// subscriber's current queue depth.
+	QueueDepth func(w io.Writer, depth int)
+
+	// Dropped is called whenever a frame is discarded instead of queued.
+	Dropped func(w io.Writer)
+
+	// Disconnected is called when a subscriber is removed because of a
+	// write error or a backpressure policy.
+	Disconnected func(w io.Writer, err error)
+}
+
+// Broadcaster is an io.Writer that fans every Write out to a set of
+// subscriber io.Writers, added and removed via Add and Remove. Each
+// subscriber is serviced by its own goroutine reading from a bounded
+// queue, so a slow subscriber can never block Write or the other
+// subscribers; QueueSize and DropPolicy control what happens when that
+// subscriber falls behind.
+type Broadcaster struct {
+	QueueSize    int
+	DropPolicy   DropPolicy
+	BlockTimeout time.Duration
+	Metrics      *BroadcasterMetrics
+
+	mu   sync.Mutex
+	subs map[io.Writer]*subscriber
+}
+
+type subscriber struct {
+	w      io.Writer
+	errc   chan<- error
+	frames chan []byte
+	stop   chan struct{}
+	once   sync.Once
+
+	// blocked backs the BlockWithTimeout policy: frames that didn't fit
+	// in frames are appended here and drained in order by a single
+	// goroutine (started on demand, see Broadcaster.enqueueBlocked), so that
+	// concurrent blocked Writes for the same subscriber can never be
+	// retried out of order.
+	blockedMu sync.Mutex
+	blocked   [][]byte
+	draining  bool
+}
+
+func (sub *subscriber) close() {
+	sub.once.Do(func() { close(sub.stop) })
+}
+
+func (br *Broadcaster) Add(w io.Writer, errc chan<- error) {
+	queueSize := br.QueueSize
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+
+	sub := &subscriber{
+		w:      w,
+		errc:   errc,
+		frames: make(chan []byte, queueSize),
+		stop:   make(chan struct{}),
+	}
+
+	br.mu.Lock()
+	if br.subs == nil {
+		br.subs = make(map[io.Writer]*subscriber)
+	}
+	br.subs[w] = sub
+	br.mu.Unlock()
+
+	go br.serve(sub)
+}
+
+func (br *Broadcaster) Remove(w io.Writer) {
+	br.mu.Lock()
+	sub, ok := br.subs[w]
+	if ok {
+		delete(br.subs, w)
+	}
+	br.mu.Unlock()
+
+	if ok {
+		sub.close()
+	}
+}
+
+// Write enqueues data for every current subscriber without waiting on any
+// subscriber's Write. It holds br.mu only while copying the subscriber
+// set, never while enqueuing or while a subscriber writes, so a slow or
+// wedged subscriber cannot stall Write or the other subscribers. Under
+// DisconnectSlowConsumer, a subscriber dropped by Write has its error
+// sent on its errc from within Write.
+func (br *Broadcaster) Write(data []byte) (int, error) {
+	frame := append([]byte(nil), data...)
+
+	br.mu.Lock()
+	subs := make([]*subscriber, 0, len(br.subs))
+	for _, sub := range br.subs {
+		subs = append(subs, sub)
+	}
+	br.mu.Unlock()
+
+	for _, sub := range subs {
+		br.enqueue(sub, frame)
+	}
+
+	return len(data), nil
+}
+
+func (br *Broadcaster) serve(sub *subscriber) {
+	for {
+		select {
+		case frame := <-sub.frames:
+			if _, err := sub.w.Write(frame); err != nil {
+				br.drop(sub, err)
+				return
+			}
+
+		case <-sub.stop:
+			return
+		}
+	}
+}
+
+// drop removes sub and reports err on its errc. Several goroutines can
+// race to drop the same subscriber (e.g. serve failing a write while the
+// BlockWithTimeout drain goroutine times out, or two Write calls both
+// seeing a full queue), so only the one that actually wins the map deletion may touch
+// sub.errc; the rest must no-op or they'd send on (or double-close) a
+// channel another winner already closed.
+func (br *Broadcaster) drop(sub *subscriber, err error) {
+	br.mu.Lock()
+	cur, ok := br.subs[sub.w]
+	won := ok && cur == sub
+	if won {
+		delete(br.subs, sub.w)
+	}
+	br.mu.Unlock()
+
+	if !won {
+		return
+	}
+
+	sub.close()
+
+	if br.Metrics != nil && br.Metrics.Disconnected != nil {
+		br.Metrics.Disconnected(sub.w, err)
+	}
+
+	if sub.errc != nil {
+		sub.errc <- err
+		close(sub.errc)
+	}
+}
+
+func (br *Broadcaster) enqueue(sub *subscriber, frame []byte) {
+	if br.Metrics != nil && br.Metrics.QueueDepth != nil {
+		br.Metrics.QueueDepth(sub.w, len(sub.frames))
+	}
+
+	// BlockWithTimeout always goes through the subscriber's own FIFO
+	// (see enqueueBlocked) rather than racing an immediate send here:
+	// letting this frame jump the queue via the fast path below, while
+	// an earlier blocked frame is still waiting its turn, would deliver
+	// them out of order.
+	if br.DropPolicy == BlockWithTimeout {
+		br.enqueueBlocked(sub, frame)
+		return
+	}
+
+	select {
+	case sub.frames <- frame:
+		return
+	case <-sub.stop:
+		return
+	default:
+	}
+
+	switch br.DropPolicy {
+	case DropNewest:
+		br.dropped(sub)
+
+	case DisconnectSlowConsumer:
+		br.drop(sub, fmt.Errorf("sse: slow consumer disconnected"))
+
+	default: // DropOldest
+		select {
+		case <-sub.frames:
+			br.dropped(sub)
+		default:
+		}
+
+		select {
+		case sub.frames <- frame:
+		default:
+			// A concurrent Write refilled the slot just freed.
+			br.dropped(sub)
+		}
+	}
+}
+
+// enqueueBlocked appends frame to sub's pending FIFO and, if no drain
+// goroutine is already running for sub, starts one. The goroutine empties
+// the FIFO one frame at a time, each waiting up to BlockTimeout for room
+// in sub.frames, so frames queued here by however many concurrent Write
+// calls are still delivered in the order they arrived.
+func (br *Broadcaster) enqueueBlocked(sub *subscriber, frame []byte) {
+	sub.blockedMu.Lock()
+	sub.blocked = append(sub.blocked, frame)
+	start := !sub.draining
+	sub.draining = true
+	sub.blockedMu.Unlock()
+
+	if start {
+		go br.drainBlocked(sub)
+	}
+}
+
+func (br *Broadcaster) drainBlocked(sub *subscriber) {
+	timeout := br.BlockTimeout
+	if timeout <= 0 {
+		timeout = DefaultBlockTimeout
+	}
+
+	for {
+		sub.blockedMu.Lock()
+		if len(sub.blocked) == 0 {
+			sub.draining = false
+			sub.blockedMu.Unlock()
+			return
+		}
+
+		frame := sub.blocked[0]
+		sub.blocked = sub.blocked[1:]
+		sub.blockedMu.Unlock()
+
+		select {
+		case sub.frames <- frame:
+
+		case <-sub.stop:
+			return
+
+		case <-time.After(timeout):
+			br.drop(sub, fmt.Errorf("sse: subscriber write timed out"))
+			return
+		}
+	}
+}
+
+func (br *Broadcaster) dropped(sub *subscriber) {
+	if br.Metrics != nil && br.Metrics.Dropped != nil {
+		br.Metrics.Dropped(sub.w)
+	}
+}
@@ -0,0 +1,229 @@
+package sse
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// gateWriter is an io.Writer that blocks every Write until release is
+// closed, then records frames in the order Write was called with them.
+// It stands in for a slow subscriber.
+type gateWriter struct {
+	release chan struct{}
+
+	mu     sync.Mutex
+	frames []string
+}
+
+func newGateWriter() *gateWriter {
+	return &gateWriter{release: make(chan struct{})}
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	<-w.release
+
+	w.mu.Lock()
+	w.frames = append(w.frames, string(p))
+	w.mu.Unlock()
+
+	return len(p), nil
+}
+
+func (w *gateWriter) snapshot() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	out := make([]string, len(w.frames))
+	copy(out, w.frames)
+
+	return out
+}
+
+func waitUntil(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met within %s", timeout)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestBroadcasterBlockWithTimeoutPreservesOrder reproduces the scenario a
+// reviewer caught: many sequential Write calls against a subscriber whose
+// queue stays full must still be delivered in the order they were
+// written, even though they pile up behind the full queue and are handed
+// on by a background goroutine.
+func TestBroadcasterBlockWithTimeoutPreservesOrder(t *testing.T) {
+	br := &Broadcaster{
+		QueueSize:    2,
+		DropPolicy:   BlockWithTimeout,
+		BlockTimeout: 2 * time.Second,
+	}
+
+	w := newGateWriter()
+	br.Add(w, nil)
+	defer br.Remove(w)
+
+	const n = 200
+
+	for i := 0; i < n; i++ {
+		if _, err := br.Write([]byte(fmt.Sprintf("%d", i))); err != nil {
+			t.Fatalf("Write: %s", err)
+		}
+	}
+
+	close(w.release)
+
+	waitUntil(t, 5*time.Second, func() bool {
+		return len(w.snapshot()) == n
+	})
+
+	got := w.snapshot()
+	for i, frame := range got {
+		if want := fmt.Sprintf("%d", i); frame != want {
+			t.Fatalf("frame %d: got %q, want %q (delivery order was violated)", i, frame, want)
+		}
+	}
+}
+
+// TestBroadcasterDropOldestKeepsNewest checks that, whatever got evicted
+// along the way, the most recently written frame is never the one
+// dropped.
+func TestBroadcasterDropOldestKeepsNewest(t *testing.T) {
+	br := &Broadcaster{QueueSize: 1, DropPolicy: DropOldest}
+
+	w := newGateWriter()
+	br.Add(w, nil)
+	defer br.Remove(w)
+
+	const n = 5
+
+	for i := 0; i < n; i++ {
+		br.Write([]byte(fmt.Sprintf("%d", i)))
+	}
+
+	close(w.release)
+
+	// Frame 0 may already be in flight when the writes start, so wait for
+	// the queue to drain as well as for a first frame to land.
+	waitUntil(t, time.Second, func() bool {
+		return len(w.snapshot()) >= 1 && len(br.subs[w].frames) == 0
+	})
+	time.Sleep(10 * time.Millisecond)
+
+	got := w.snapshot()
+	if last := got[len(got)-1]; last != fmt.Sprintf("%d", n-1) {
+		t.Fatalf("expected the newest frame to survive drop-oldest, got %q", last)
+	}
+}
+
+// TestBroadcasterDropNewestDiscardsIncoming checks that DropNewest drops
+// the frame that didn't fit rather than one already queued.
+func TestBroadcasterDropNewestDiscardsIncoming(t *testing.T) {
+	var dropped int32
+
+	br := &Broadcaster{
+		QueueSize:  1,
+		DropPolicy: DropNewest,
+		Metrics: &BroadcasterMetrics{
+			Dropped: func(io.Writer) { atomic.AddInt32(&dropped, 1) },
+		},
+	}
+
+	w := newGateWriter()
+	br.Add(w, nil)
+	defer br.Remove(w)
+
+	for i := 0; i < 5; i++ {
+		br.Write([]byte(fmt.Sprintf("%d", i)))
+	}
+
+	close(w.release)
+
+	waitUntil(t, time.Second, func() bool {
+		return len(w.snapshot()) >= 1
+	})
+
+	if atomic.LoadInt32(&dropped) == 0 {
+		t.Fatalf("expected at least one frame to be reported dropped")
+	}
+
+	// Frame 0 always fits the empty queue, so it must be the one kept.
+	if first := w.snapshot()[0]; first != "0" {
+		t.Fatalf("expected the already-queued frame %q to survive drop-newest, got %q", "0", first)
+	}
+}
+
+// TestBroadcasterDisconnectSlowConsumer checks that a full queue
+// disconnects the subscriber and reports an error on errc.
+func TestBroadcasterDisconnectSlowConsumer(t *testing.T) {
+	br := &Broadcaster{QueueSize: 1, DropPolicy: DisconnectSlowConsumer}
+
+	w := newGateWriter()
+	errc := make(chan error, 1)
+
+	br.Add(w, errc)
+	defer close(w.release)
+
+	for i := 0; i < 5; i++ {
+		br.Write([]byte(fmt.Sprintf("%d", i)))
+	}
+
+	select {
+	case err := <-errc:
+		if err == nil {
+			t.Fatalf("expected a non-nil disconnect error")
+		}
+
+	case <-time.After(time.Second):
+		t.Fatalf("expected the slow subscriber to be disconnected")
+	}
+}
+
+// TestBroadcasterConcurrentAddRemoveWrite exercises Add, Remove and Write
+// from many goroutines at once; run with -race, it would have caught the
+// send-on-closed-channel and lock-inversion bugs fixed in this series.
+func TestBroadcasterConcurrentAddRemoveWrite(t *testing.T) {
+	br := &Broadcaster{QueueSize: 4, DropPolicy: DropOldest}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 20; j++ {
+				w := &bytes.Buffer{}
+
+				br.Add(w, nil)
+				br.Write([]byte("x"))
+				br.Remove(w)
+			}
+		}()
+	}
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 50; j++ {
+				br.Write([]byte("y"))
+			}
+		}()
+	}
+
+	wg.Wait()
+}
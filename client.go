@@ -0,0 +1,228 @@
+package sse
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ClientStatus describes the current state of a Client's connection.
+type ClientStatus int
+
+const (
+	StatusConnecting ClientStatus = iota
+	StatusOpen
+	StatusError
+	StatusClosed
+)
+
+func (s ClientStatus) String() string {
+	switch s {
+	case StatusConnecting:
+		return "connecting"
+	case StatusOpen:
+		return "open"
+	case StatusError:
+		return "error"
+	case StatusClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	DefaultBaseDelay = 1 * time.Second
+	DefaultMaxDelay  = 30 * time.Second
+)
+
+// Client consumes an SSE endpoint, reconnecting on error or EOF and
+// resuming from the last received Id via the Last-Event-ID header.
+type Client struct {
+	HTTPClient *http.Client
+	Header     http.Header
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+	OnStatus   func(status ClientStatus, err error)
+
+	url string
+
+	mu     sync.Mutex
+	lastId string
+	retry  time.Duration
+
+	events chan *Event
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewClient creates a Client for the given URL. The connection is not
+// started until Run is called.
+func NewClient(url string) *Client {
+	return &Client{
+		url:    url,
+		events: make(chan *Event),
+		done:   make(chan struct{}),
+	}
+}
+
+// Events returns the channel on which received events are delivered.
+func (c *Client) Events() <-chan *Event {
+	return c.events
+}
+
+// Run connects to the endpoint and blocks, delivering events to the
+// Events channel and reconnecting until ctx is done or Close is called.
+func (c *Client) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+
+	c.mu.Lock()
+	c.cancel = cancel
+	c.mu.Unlock()
+
+	defer close(c.events)
+	defer close(c.done)
+
+	baseDelay := c.BaseDelay
+	if baseDelay == 0 {
+		baseDelay = DefaultBaseDelay
+	}
+
+	maxDelay := c.MaxDelay
+	if maxDelay == 0 {
+		maxDelay = DefaultMaxDelay
+	}
+
+	for attempt := 0; ; attempt++ {
+		c.setStatus(StatusConnecting, nil)
+
+		err := c.connect(ctx)
+
+		if ctx.Err() != nil {
+			c.setStatus(StatusClosed, nil)
+			return nil
+		}
+
+		c.setStatus(StatusError, err)
+
+		delay := c.nextDelay(baseDelay, maxDelay, attempt)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			c.setStatus(StatusClosed, nil)
+			return nil
+		}
+	}
+}
+
+// Close stops the client and waits for Run to return.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	cancel := c.cancel
+	c.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+
+	<-c.done
+
+	return nil
+}
+
+func (c *Client) connect(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return err
+	}
+
+	for k, vs := range c.Header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+
+	c.mu.Lock()
+	lastId := c.lastId
+	c.mu.Unlock()
+
+	if lastId != "" {
+		req.Header.Set("Last-Event-ID", lastId)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("sse: unexpected status %s", resp.Status)
+	}
+
+	c.setStatus(StatusOpen, nil)
+
+	dec := NewDecoder(resp.Body)
+
+	for dec.More() {
+		ev := &Event{}
+
+		if err := dec.Decode(ev); err != nil {
+			return err
+		}
+
+		if ev.Id != "" {
+			c.mu.Lock()
+			c.lastId = ev.Id
+			c.mu.Unlock()
+		}
+
+		if ev.Retry != 0 {
+			c.mu.Lock()
+			c.retry = ev.Retry
+			c.mu.Unlock()
+		}
+
+		select {
+		case c.events <- ev:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+
+	return dec.Err()
+}
+
+func (c *Client) nextDelay(baseDelay, maxDelay time.Duration, attempt int) time.Duration {
+	c.mu.Lock()
+	retry := c.retry
+	c.mu.Unlock()
+
+	if retry != 0 {
+		return retry
+	}
+
+	delay := baseDelay << uint(attempt)
+	if delay <= 0 || delay > maxDelay {
+		delay = maxDelay
+	}
+
+	// Full jitter: pick uniformly in [0, delay).
+	return time.Duration(rand.Int63n(int64(delay)))
+}
+
+func (c *Client) setStatus(status ClientStatus, err error) {
+	if c.OnStatus != nil {
+		c.OnStatus(status, err)
+	}
+}
@@ -3,6 +3,7 @@ package sse // import "go.pdmccormick.com/sse"
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -94,20 +95,15 @@ func (ev *Event) WriteTo(w io.Writer) (n int64, err error) {
 type Decoder struct {
 	s       *bufio.Scanner
 	b       bytes.Buffer
-	j       *json.Decoder
 	hasNext bool
 	ev      Event
 	err     error
 }
 
 func NewDecoder(r io.Reader) *Decoder {
-	dec := &Decoder{
+	return &Decoder{
 		s: bufio.NewScanner(r),
 	}
-
-	dec.j = json.NewDecoder(&dec.b)
-
-	return dec
 }
 
 func (dec *Decoder) More() bool {
@@ -234,7 +230,12 @@ func (dec *Decoder) Decode(ev *Event) error {
 	dec.hasNext = false
 
 	if dec.b.Len() > 0 && data != nil {
-		if err := dec.j.Decode(data); err != nil {
+		// A fresh json.Decoder per event: dec.b is reused across events,
+		// so a decoder built against it once would see stale state (a
+		// partial read or trailing bytes) bleed into the next Decode.
+		je := json.NewDecoder(dec.DataReader())
+
+		if err := je.Decode(data); err != nil {
 			return err
 		}
 	}
@@ -242,71 +243,42 @@ func (dec *Decoder) Decode(ev *Event) error {
 	return nil
 }
 
-type Broadcaster struct {
-	mu sync.RWMutex
-	ws map[io.Writer]chan<- error
-}
-
-func (br *Broadcaster) Add(w io.Writer, errc chan<- error) {
-	br.mu.Lock()
-	defer br.mu.Unlock()
-
-	if br.ws == nil {
-		br.ws = make(map[io.Writer]chan<- error)
-	}
-
-	br.ws[w] = errc
-}
-
-func (br *Broadcaster) Remove(w io.Writer) {
-	br.mu.Lock()
-	defer br.mu.Unlock()
-
-	if br.ws == nil {
-		return
-	}
-
-	delete(br.ws, w)
+// DataReader returns an io.Reader over the concatenated "data:" lines of
+// the event most recently returned by Decode, letting a caller drive its
+// own json.Decoder, bufio.Scanner, or protobuf reader over the raw bytes
+// instead of going through the Data interface{} reflection path. It is
+// only valid until the next call to Decode or More, which reuse and
+// overwrite the underlying buffer.
+func (dec *Decoder) DataReader() io.Reader {
+	return bytes.NewReader(dec.b.Bytes())
 }
 
-func (br *Broadcaster) Write(data []byte) (int, error) {
-	br.mu.RLock()
-	defer br.mu.RUnlock()
-
-	if br.ws == nil {
-		return 0, nil
-	}
-
-	wg := &sync.WaitGroup{}
-
-	for w, errc := range br.ws {
-		var (
-			w    = w
-			errc = errc
-		)
-
-		wg.Add(1)
-
-		go func() {
-			defer wg.Done()
+// Stream calls fn with every decoded event until the underlying reader
+// is exhausted or fn returns a non-nil error, which Stream returns as-is.
+// ctx cancellation is only observed between events, not while a call to
+// the underlying reader is blocked (e.g. a stalled connection); callers
+// that need to interrupt a blocked read should arrange for ctx's
+// cancellation to close the underlying reader. This suits callers such
+// as multi-event JSON streaming APIs that want a single blocking loop
+// rather than driving More/Decode themselves.
+func (dec *Decoder) Stream(ctx context.Context, fn func(*Event) error) error {
+	for dec.More() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 
-			if _, err := w.Write(data); err != nil {
-				br.mu.Lock()
-				defer br.mu.Unlock()
+		ev := &Event{}
 
-				delete(br.ws, w)
+		if err := dec.Decode(ev); err != nil {
+			return err
+		}
 
-				if errc != nil {
-					errc <- err
-					close(errc)
-				}
-			}
-		}()
+		if err := fn(ev); err != nil {
+			return err
+		}
 	}
 
-	wg.Wait()
-
-	return len(data), nil
+	return dec.Err()
 }
 
 const (
@@ -332,6 +304,12 @@ type flushWriter struct {
 	mu sync.Mutex
 	w  io.Writer
 	f  http.Flusher
+
+	// lastWrite is the time of the last successful write, real or
+	// heartbeat. EventWriterWithKeepalive sets it first, after which Write
+	// keeps it current so the keepalive goroutine skips a heartbeat soon
+	// after a real write; it is the zero Time when no keepalive is running.
+	lastWrite time.Time
 }
 
 func (fw *flushWriter) Write(data []byte) (int, error) {
@@ -341,5 +319,9 @@ func (fw *flushWriter) Write(data []byte) (int, error) {
 	n, err := fw.w.Write(data)
 	fw.f.Flush()
 
+	if err == nil && !fw.lastWrite.IsZero() {
+		fw.lastWrite = time.Now()
+	}
+
 	return n, err
 }
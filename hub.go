@@ -0,0 +1,290 @@
+package sse
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Hub fans events out to subscribers grouped by topic. It sits on top of
+// a Broadcaster per topic, so the existing single-topic fan-out behavior
+// of Broadcaster is reused unchanged; callers who don't care about topics
+// can just use a single Hub topic (or a bare Broadcaster).
+type Hub struct {
+	// Store, if set, records published events so that Subscribe can
+	// replay catch-up events to a subscriber presenting a Last-Event-ID.
+	Store Store
+
+	mu        sync.RWMutex
+	topics    map[string]*Broadcaster
+	wildcards map[string]*Broadcaster // glob pattern -> broadcaster, e.g. "foo.*"
+}
+
+func (h *Hub) broadcasterFor(topic string) *Broadcaster {
+	m := &h.topics
+	if strings.ContainsAny(topic, "*?[") {
+		m = &h.wildcards
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if *m == nil {
+		*m = make(map[string]*Broadcaster)
+	}
+
+	br, ok := (*m)[topic]
+	if !ok {
+		br = &Broadcaster{}
+		(*m)[topic] = br
+	}
+
+	return br
+}
+
+// Add registers w to receive events published to any of topics. A topic
+// containing glob metacharacters (as understood by path.Match) matches
+// any published topic name, e.g. "foo.*".
+func (h *Hub) Add(w io.Writer, errc chan<- error, topics ...string) {
+	for _, topic := range topics {
+		h.broadcasterFor(topic).Add(w, errc)
+	}
+}
+
+// Remove unregisters w from every topic it was added to.
+func (h *Hub) Remove(w io.Writer) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, br := range h.topics {
+		br.Remove(w)
+	}
+
+	for _, br := range h.wildcards {
+		br.Remove(w)
+	}
+}
+
+// Publish writes ev to every subscriber of topic, including subscribers
+// registered under a glob pattern that matches topic.
+func (h *Hub) Publish(topic string, ev *Event) error {
+	if h.Store != nil {
+		h.Store.Append(topic, ev)
+	}
+
+	h.mu.RLock()
+	br := h.topics[topic]
+
+	var matched []*Broadcaster
+	for pattern, wbr := range h.wildcards {
+		if ok, _ := path.Match(pattern, topic); ok {
+			matched = append(matched, wbr)
+		}
+	}
+	h.mu.RUnlock()
+
+	if br != nil {
+		if _, err := ev.WriteTo(br); err != nil {
+			return err
+		}
+	}
+
+	for _, wbr := range matched {
+		if _, err := ev.WriteTo(wbr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PublishHandler returns a POST handler that decodes the request body as
+// a single JSON value, wraps it as an Event, and publishes it to the
+// topic(s) named by Topics(r, pathPrefix). It mirrors the chatserver
+// demo's handlePost, keyed by topic instead of a single global bus.
+func (h *Hub) PublishHandler(pathPrefix string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var raw json.RawMessage
+
+		if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+
+		topics := Topics(r, pathPrefix)
+		if len(topics) == 0 {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+
+		compact := &bytes.Buffer{}
+		if err := json.Compact(compact, raw); err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+
+		ev := &Event{RawData: compact.String()}
+
+		for _, topic := range topics {
+			if err := h.Publish(topic, ev); err != nil {
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+}
+
+// Topics returns the topics requested by a request, reading either a
+// comma-separated "?topic=foo,bar" query parameter or, if pathPrefix is
+// non-empty, a trailing path segment such as "/events/foo,bar". The
+// match against pathPrefix requires a "/" (or end of path) right after
+// it, so an unrelated route sharing the same string prefix (e.g.
+// "/eventsXfoo" against pathPrefix "/events") is never mistaken for one.
+func Topics(r *http.Request, pathPrefix string) []string {
+	if q := r.URL.Query().Get("topic"); q != "" {
+		return strings.Split(q, ",")
+	}
+
+	if pathPrefix != "" {
+		rest := strings.TrimPrefix(r.URL.Path, pathPrefix)
+		if rest == r.URL.Path {
+			return nil
+		}
+
+		if rest != "" && rest[0] != '/' {
+			return nil
+		}
+
+		if rest = strings.Trim(rest, "/"); rest != "" {
+			return strings.Split(rest, ",")
+		}
+	}
+
+	return nil
+}
+
+// Subscribe upgrades r/w to an SSE stream and registers it with h for the
+// given topics, returning the writer to use for any immediate events and
+// a channel that receives a non-nil error if the subscriber is dropped.
+//
+// If the request carries a Last-Event-ID header and h.Store is set,
+// Subscribe replays every buffered event after that id for each of
+// topics (exact names only; glob patterns aren't replayed) before the
+// connection sees any live event. Subscribe registers with h before
+// running the replay so a Publish racing the replay is never lost: it is
+// held in a small buffer and flushed, in order, right after the replay.
+// Any buffered event whose Id was already replayed is dropped from that
+// flush, so a Publish landing in the registration/replay window is
+// delivered exactly once rather than twice.
+func (h *Hub) Subscribe(w http.ResponseWriter, r *http.Request, topics ...string) (io.Writer, <-chan error, error) {
+	ew, err := EventWriter(w)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bw := &bufferedWriter{w: ew}
+	errc := make(chan error, 1)
+
+	h.Add(bw, errc, topics...)
+
+	replayed := map[string]bool{}
+
+	if h.Store != nil {
+		if lastId := r.Header.Get("Last-Event-ID"); lastId != "" {
+			for _, topic := range topics {
+				for _, ev := range h.Store.Since(topic, lastId) {
+					if _, err := ev.WriteTo(ew); err != nil {
+						return nil, nil, err
+					}
+
+					if ev.Id != "" {
+						replayed[ev.Id] = true
+					}
+				}
+			}
+		}
+	}
+
+	if err := bw.flush(replayed); err != nil {
+		return nil, nil, err
+	}
+
+	return bw, errc, nil
+}
+
+// bufferedWriter queues writes in memory until flush is called, then
+// switches to passing writes straight through to w. It lets Subscribe
+// register a live subscriber before the replay has been written, without
+// interleaving live frames ahead of replayed ones.
+type bufferedWriter struct {
+	mu    sync.Mutex
+	w     io.Writer
+	ready bool
+	queue [][]byte
+}
+
+func (bw *bufferedWriter) Write(data []byte) (int, error) {
+	bw.mu.Lock()
+
+	if !bw.ready {
+		bw.queue = append(bw.queue, append([]byte(nil), data...))
+		bw.mu.Unlock()
+		return len(data), nil
+	}
+
+	bw.mu.Unlock()
+
+	return bw.w.Write(data)
+}
+
+// flush writes every buffered frame to w, then switches bw into
+// passthrough mode. A frame whose Id is in skip is dropped instead of
+// written, so a frame already delivered by a replay isn't delivered
+// again here.
+func (bw *bufferedWriter) flush(skip map[string]bool) error {
+	bw.mu.Lock()
+	queue := bw.queue
+	bw.queue = nil
+	bw.ready = true
+	bw.mu.Unlock()
+
+	for _, data := range queue {
+		if len(skip) > 0 {
+			if id := frameId(data); id != "" && skip[id] {
+				continue
+			}
+		}
+
+		if _, err := bw.w.Write(data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// frameId extracts the "id:" field from a single SSE-encoded frame, or
+// "" if it has none.
+func frameId(data []byte) string {
+	dec := NewDecoder(bytes.NewReader(data))
+
+	if !dec.More() {
+		return ""
+	}
+
+	ev := &Event{}
+	if err := dec.Decode(ev); err != nil {
+		return ""
+	}
+
+	return ev.Id
+}
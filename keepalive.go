@@ -0,0 +1,75 @@
+package sse
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+const DefaultKeepaliveInterval = 15 * time.Second
+
+// EventWriterWithKeepalive is EventWriter plus a periodic ":heartbeat\n\n"
+// comment frame sent whenever interval passes without a real write. This
+// keeps intermediate proxies from closing an otherwise idle connection
+// and lets the client's TCP stack notice a dead peer. Writing through the
+// returned io.Writer resets the idle timer, and heartbeats are sent under
+// the same mutex as those writes so they can never interleave mid-frame.
+// The keepalive goroutine exits once r's context is cancelled.
+func EventWriterWithKeepalive(w http.ResponseWriter, r *http.Request, interval time.Duration) (io.Writer, error) {
+	if interval <= 0 {
+		interval = DefaultKeepaliveInterval
+	}
+
+	iw, err := EventWriter(w)
+	if err != nil {
+		return nil, err
+	}
+
+	fw := iw.(*flushWriter)
+
+	fw.mu.Lock()
+	fw.lastWrite = time.Now()
+	fw.mu.Unlock()
+
+	go runKeepalive(r.Context(), fw, interval)
+
+	return fw, nil
+}
+
+func runKeepalive(ctx context.Context, fw *flushWriter, interval time.Duration) {
+	// Polling fw.lastWrite on a ticker, rather than resetting a
+	// time.Timer from both this goroutine and flushWriter.Write, avoids
+	// the well-known race around time.Timer.Reset racing a pending tick.
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	heartbeat := []byte(":heartbeat\n\n")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			fw.mu.Lock()
+
+			if time.Since(fw.lastWrite) < interval {
+				fw.mu.Unlock()
+				continue
+			}
+
+			_, err := fw.w.Write(heartbeat)
+			if err == nil {
+				fw.f.Flush()
+				fw.lastWrite = time.Now()
+			}
+
+			fw.mu.Unlock()
+
+			if err != nil {
+				return
+			}
+		}
+	}
+}
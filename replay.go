@@ -0,0 +1,104 @@
+package sse
+
+import (
+	"sync"
+	"time"
+)
+
+// Store persists recently published events per topic so a newly
+// connected subscriber can replay anything it missed, keyed by Event.Id.
+// The default, returned by NewMemoryStore, keeps a bounded ring buffer in
+// memory; implementing Store against Redis or a database lets a Hub
+// replay catch-up events across a horizontally scaled deployment.
+type Store interface {
+	// Append records ev as having been published to topic.
+	Append(topic string, ev *Event)
+
+	// Since returns every event recorded for topic after lastId, oldest
+	// first. It returns nothing if lastId is empty or not found.
+	Since(topic string, lastId string) []*Event
+}
+
+type ringEntry struct {
+	ev    *Event
+	stamp time.Time
+}
+
+// memoryStore is the default in-memory Store. Entries are evicted by
+// both count (Size) and age (MaxAge); either may be left at zero to
+// disable that limit.
+type memoryStore struct {
+	Size   int
+	MaxAge time.Duration
+
+	mu     sync.Mutex
+	topics map[string][]ringEntry
+}
+
+// NewMemoryStore returns a Store backed by an in-memory ring buffer per
+// topic, holding at most size entries (0 means unbounded) no older than
+// maxAge (0 means no age limit).
+func NewMemoryStore(size int, maxAge time.Duration) Store {
+	return &memoryStore{
+		Size:   size,
+		MaxAge: maxAge,
+		topics: make(map[string][]ringEntry),
+	}
+}
+
+func (s *memoryStore) Append(topic string, ev *Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := append(s.topics[topic], ringEntry{ev: ev, stamp: time.Now()})
+	s.topics[topic] = s.evictLocked(entries)
+}
+
+func (s *memoryStore) evictLocked(entries []ringEntry) []ringEntry {
+	if s.MaxAge > 0 {
+		cutoff := time.Now().Add(-s.MaxAge)
+
+		i := 0
+		for i < len(entries) && entries[i].stamp.Before(cutoff) {
+			i++
+		}
+
+		entries = entries[i:]
+	}
+
+	if s.Size > 0 && len(entries) > s.Size {
+		entries = entries[len(entries)-s.Size:]
+	}
+
+	return entries
+}
+
+func (s *memoryStore) Since(topic string, lastId string) []*Event {
+	if lastId == "" {
+		return nil
+	}
+
+	// Snapshot the (evicted) slice under lock, then search and copy
+	// outside of it so a concurrent Append never blocks on a slow
+	// replay, and a slow replay never blocks a concurrent Append.
+	s.mu.Lock()
+	entries := s.evictLocked(s.topics[topic])
+	s.topics[topic] = entries
+	snapshot := append([]ringEntry(nil), entries...)
+	s.mu.Unlock()
+
+	i := 0
+	for ; i < len(snapshot); i++ {
+		if snapshot[i].ev.Id == lastId {
+			i++
+			break
+		}
+	}
+
+	out := make([]*Event, 0, len(snapshot)-i)
+	for ; i < len(snapshot); i++ {
+		out = append(out, snapshot[i].ev)
+	}
+
+	return out
+}
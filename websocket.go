@@ -0,0 +1,212 @@
+package sse
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocketEncoding selects how an Event is framed for a WebSocket
+// connection.
+type WebSocketEncoding int
+
+const (
+	// WebSocketRaw sends the same bytes Event.WriteTo would write to an
+	// SSE stream, as a single text frame.
+	WebSocketRaw WebSocketEncoding = iota
+
+	// WebSocketJSON sends a {id, event, data, retry} envelope as a single
+	// text frame instead.
+	WebSocketJSON
+)
+
+var wsUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// wsWriter adapts a *websocket.Conn to the io.Writer interface expected
+// by Broadcaster.Add, framing every Write as one text message so a
+// WebSocket client can be a Broadcaster/Hub subscriber just like an
+// EventWriter. Its own keepalive goroutine (see runWSKeepalive) writes
+// under mu too, so a heartbeat can never interleave with a real frame.
+type wsWriter struct {
+	mu        sync.Mutex
+	conn      *websocket.Conn
+	encoding  WebSocketEncoding
+	lastWrite time.Time
+}
+
+type wsEnvelope struct {
+	Id    string `json:"id,omitempty"`
+	Event string `json:"event,omitempty"`
+	Data  string `json:"data,omitempty"`
+	Retry int64  `json:"retry,omitempty"`
+}
+
+func (w *wsWriter) Write(data []byte) (int, error) {
+	payload := data
+
+	if w.encoding == WebSocketJSON {
+		ev := &Event{}
+		dec := NewDecoder(bytes.NewReader(data))
+
+		if dec.More() {
+			if err := dec.Decode(ev); err != nil {
+				return 0, err
+			}
+		}
+
+		b, err := json.Marshal(wsEnvelope{
+			Id:    ev.Id,
+			Event: ev.Event,
+			Data:  ev.RawData,
+			Retry: ev.Retry.Milliseconds(),
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		payload = b
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+		return 0, err
+	}
+
+	w.lastWrite = time.Now()
+
+	return len(data), nil
+}
+
+// ping writes a WebSocket ping frame if nothing has been written since
+// interval ago, mirroring EventWriterWithKeepalive's heartbeat comment
+// for SSE subscribers.
+func (w *wsWriter) ping(interval time.Duration) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if time.Since(w.lastWrite) < interval {
+		return nil
+	}
+
+	if err := w.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+		return err
+	}
+
+	w.lastWrite = time.Now()
+
+	return nil
+}
+
+// runWSKeepalive pings ws on a fixed interval, the same idle-check
+// approach EventWriterWithKeepalive uses for plain SSE connections, so
+// idle WebSocket subscribers get the same proxy/dead-peer protection.
+// It exits once ctx is done or a ping fails.
+func runWSKeepalive(ctx context.Context, ws *wsWriter, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			if err := ws.ping(interval); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// WebSocketHandler upgrades r to a WebSocket connection and registers it
+// with br as an ordinary Broadcaster subscriber, so the same publisher
+// pipeline that feeds EventSource clients over SSE also reaches
+// WebSocket clients without duplicating any broadcast logic. keepalive is
+// the ping interval for idle connections (see EventWriterWithKeepalive);
+// 0 uses DefaultKeepaliveInterval.
+func WebSocketHandler(br *Broadcaster, encoding WebSocketEncoding, keepalive time.Duration) http.HandlerFunc {
+	if keepalive <= 0 {
+		keepalive = DefaultKeepaliveInterval
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		conn, err := wsUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+
+		defer conn.Close()
+
+		ws := &wsWriter{conn: conn, encoding: encoding, lastWrite: time.Now()}
+		errc := make(chan error, 1)
+
+		br.Add(ws, errc)
+		defer br.Remove(ws)
+
+		go runWSKeepalive(r.Context(), ws, keepalive)
+
+		go func() {
+			select {
+			case <-errc:
+			case <-r.Context().Done():
+			}
+
+			conn.Close()
+		}()
+
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// HubWebSocketHandler is WebSocketHandler for a Hub, subscribing the
+// connection to the given topics.
+func HubWebSocketHandler(hub *Hub, encoding WebSocketEncoding, keepalive time.Duration, topics ...string) http.HandlerFunc {
+	if keepalive <= 0 {
+		keepalive = DefaultKeepaliveInterval
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		conn, err := wsUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+
+		defer conn.Close()
+
+		ws := &wsWriter{conn: conn, encoding: encoding, lastWrite: time.Now()}
+		errc := make(chan error, 1)
+
+		hub.Add(ws, errc, topics...)
+		defer hub.Remove(ws)
+
+		go runWSKeepalive(r.Context(), ws, keepalive)
+
+		go func() {
+			select {
+			case <-errc:
+			case <-r.Context().Done():
+			}
+
+			conn.Close()
+		}()
+
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}
+}